@@ -0,0 +1,55 @@
+package clarifai
+
+import (
+	"math/rand"
+	"time"
+)
+
+// BackoffStrategy controls the pause between retried requests.
+type BackoffStrategy interface {
+	// Next returns the delay to wait before retrying the given attempt
+	// (0-indexed: the first retry is attempt 0).
+	Next(attempt int) time.Duration
+
+	// Reset returns the strategy to its initial state.
+	Reset()
+}
+
+// ExponentialBackoff is the default BackoffStrategy: the delay doubles
+// with each attempt, capped at Cap, then scaled by a random factor in
+// [1-Jitter, 1+Jitter] (a Jitter of 0.2 means +/- 20%).
+type ExponentialBackoff struct {
+	Base   time.Duration
+	Cap    time.Duration
+	Jitter float64
+}
+
+// NewExponentialBackoff returns an ExponentialBackoff with sensible
+// defaults: a 250ms base, a 30s cap, and 20% jitter.
+func NewExponentialBackoff() *ExponentialBackoff {
+	return &ExponentialBackoff{
+		Base:   250 * time.Millisecond,
+		Cap:    30 * time.Second,
+		Jitter: 0.2,
+	}
+}
+
+// Next returns min(Cap, Base*2^attempt) scaled by a random factor in
+// [1-Jitter, 1+Jitter].
+func (b *ExponentialBackoff) Next(attempt int) time.Duration {
+	delay := b.Base << uint(attempt)
+	if delay <= 0 || delay > b.Cap {
+		delay = b.Cap
+	}
+
+	if b.Jitter <= 0 {
+		return delay
+	}
+
+	factor := 1 - b.Jitter + rand.Float64()*2*b.Jitter
+
+	return time.Duration(float64(delay) * factor)
+}
+
+// Reset is a no-op for ExponentialBackoff, which carries no attempt state.
+func (b *ExponentialBackoff) Reset() {}
@@ -0,0 +1,374 @@
+package clarifai
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Cache stores marshaled Tag/Color results keyed by a content hash, so
+// repeated requests for the same input skip the API entirely. Client's
+// default is NoopCache, so caching is opt-in.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// NoopCache never stores or returns anything.
+type NoopCache struct{}
+
+// Get always reports a miss.
+func (NoopCache) Get(key string) ([]byte, bool) { return nil, false }
+
+// Set is a no-op.
+func (NoopCache) Set(key string, value []byte, ttl time.Duration) {}
+
+// CacheStats tracks cumulative activity for a MemoryCache.
+type CacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+}
+
+type cacheEntry struct {
+	key      string
+	value    []byte
+	expireAt time.Time
+}
+
+// MemoryCache is an in-memory Cache with TTL expiry and LRU eviction
+// once it holds more than its capacity of entries.
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+	stats    CacheStats
+}
+
+// NewMemoryCache returns a MemoryCache that evicts its least recently
+// used entry once it holds more than capacity items. A capacity of 0
+// means unbounded.
+func NewMemoryCache(capacity int) *MemoryCache {
+	return &MemoryCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached value for key, evicting it first if its TTL
+// has expired.
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+
+	entry := el.Value.(*cacheEntry)
+	if !entry.expireAt.IsZero() && time.Now().After(entry.expireAt) {
+		c.removeElement(el)
+		c.stats.Misses++
+		return nil, false
+	}
+
+	c.ll.MoveToFront(el)
+	c.stats.Hits++
+
+	return entry.value, true
+}
+
+// Set stores value under key. A ttl of 0 means the entry never expires
+// on its own, though it can still be evicted under LRU pressure.
+func (c *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl)
+	}
+
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		entry := el.Value.(*cacheEntry)
+		entry.value = value
+		entry.expireAt = expireAt
+		return
+	}
+
+	el := c.ll.PushFront(&cacheEntry{key: key, value: value, expireAt: expireAt})
+	c.items[key] = el
+
+	if c.capacity > 0 && c.ll.Len() > c.capacity {
+		c.evictOldest()
+	}
+}
+
+func (c *MemoryCache) evictOldest() {
+	el := c.ll.Back()
+	if el == nil {
+		return
+	}
+
+	c.removeElement(el)
+	c.stats.Evictions++
+}
+
+func (c *MemoryCache) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*cacheEntry).key)
+}
+
+// Stats returns a snapshot of the cache's cumulative hit/miss/eviction
+// counts.
+func (c *MemoryCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats
+}
+
+func (client *Client) cache() Cache {
+	if client.Cache != nil {
+		return client.Cache
+	}
+
+	return NoopCache{}
+}
+
+// cacheEnabled reports whether Client.Cache is configured. Tag/Color use
+// this to skip hashing inputs into cache keys entirely when caching is
+// disabled, since the key would only be computed and discarded.
+func (client *Client) cacheEnabled() bool {
+	_, noop := client.cache().(NoopCache)
+	return !noop
+}
+
+// CacheStats returns the client's cache activity, or a zero value if
+// Client.Cache isn't a *MemoryCache (which is the only implementation
+// that tracks stats).
+func (client *Client) CacheStats() CacheStats {
+	if mc, ok := client.Cache.(*MemoryCache); ok {
+		return mc.Stats()
+	}
+
+	return CacheStats{}
+}
+
+// cacheKey derives a per-input cache key from the model and either a
+// URL or a file's content hash, so a renamed/copied file still hits the
+// cache.
+func cacheKey(model, id string) string {
+	sum := sha256.Sum256([]byte(model + "|" + id))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashFileContent streams name through sha256 so its cache key is
+// derived from content rather than path.
+func hashFileContent(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fileHashEntry is a cached hashFileContent result, valid as long as
+// name's mtime and size haven't changed.
+type fileHashEntry struct {
+	modTime time.Time
+	size    int64
+	hash    string
+}
+
+// fileHashCache memoizes per-file content hashes keyed by mtime+size, so
+// repeated Tag/Color calls over an unchanged file (the common case when
+// iterating locally) skip re-reading and re-hashing it.
+type fileHashCache struct {
+	mu      sync.Mutex
+	entries map[string]fileHashEntry
+}
+
+// fileContentHash returns name's sha256 content hash, reusing the
+// client's cached hash if name's mtime and size match what was last
+// seen. A file being hashed for the first time, or one that changed,
+// still costs one read: there's no way to know a file's cache key
+// without hashing its content.
+func (client *Client) fileContentHash(name string) (string, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return "", err
+	}
+
+	client.fileHashCache.mu.Lock()
+	if entry, ok := client.fileHashCache.entries[name]; ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		client.fileHashCache.mu.Unlock()
+		return entry.hash, nil
+	}
+	client.fileHashCache.mu.Unlock()
+
+	hash, err := hashFileContent(name)
+	if err != nil {
+		return "", err
+	}
+
+	client.fileHashCache.mu.Lock()
+	if client.fileHashCache.entries == nil {
+		client.fileHashCache.entries = make(map[string]fileHashEntry)
+	}
+	client.fileHashCache.entries[name] = fileHashEntry{modTime: info.ModTime(), size: info.Size(), hash: hash}
+	client.fileHashCache.mu.Unlock()
+
+	return hash, nil
+}
+
+// inputCacheKeys returns a cache key per URL or per file in inputs,
+// hashing file contents so a renamed/copied file still hits the cache.
+// Callers should only reach this when client.cacheEnabled(), since the
+// file read/hash it costs is wasted if the key is never looked up.
+func (client *Client) inputCacheKeys(model string, urls, files []string) ([]string, error) {
+	if len(urls) > 0 {
+		keys := make([]string, len(urls))
+		for i, u := range urls {
+			keys[i] = cacheKey(model, u)
+		}
+
+		return keys, nil
+	}
+
+	keys := make([]string, len(files))
+	for i, name := range files {
+		hash, err := client.fileContentHash(name)
+		if err != nil {
+			return nil, err
+		}
+
+		keys[i] = cacheKey(model, hash)
+	}
+
+	return keys, nil
+}
+
+// tagCacheHits splits keys into already-cached TagResults and the
+// indices that still need to be fetched from the API.
+func (client *Client) tagCacheHits(keys []string, bypass bool) (cached []*TagResult, missing []int) {
+	cached = make([]*TagResult, len(keys))
+
+	if bypass {
+		for i := range keys {
+			missing = append(missing, i)
+		}
+
+		return cached, missing
+	}
+
+	cache := client.cache()
+	for i, key := range keys {
+		raw, ok := cache.Get(key)
+		if !ok {
+			missing = append(missing, i)
+			continue
+		}
+
+		var result TagResult
+		if err := json.Unmarshal(raw, &result); err != nil {
+			missing = append(missing, i)
+			continue
+		}
+
+		cached[i] = &result
+	}
+
+	return cached, missing
+}
+
+// assembleTagResp splices cached results back into original-request
+// order, using source (the response for any cache misses, or nil if
+// every input was a hit) for the envelope fields.
+func assembleTagResp(source *TagResp, cached []*TagResult) *TagResp {
+	resp := &TagResp{Results: make([]TagResult, len(cached))}
+
+	if source != nil {
+		resp.StatusCode = source.StatusCode
+		resp.StatusMessage = source.StatusMessage
+		resp.Meta = source.Meta
+	} else {
+		resp.StatusCode = "OK"
+		resp.StatusMessage = "OK"
+	}
+
+	for i, r := range cached {
+		if r != nil {
+			resp.Results[i] = *r
+		}
+	}
+
+	return resp
+}
+
+// colorCacheHits splits keys into already-cached ColorResults and the
+// indices that still need to be fetched from the API.
+func (client *Client) colorCacheHits(keys []string, bypass bool) (cached []*ColorResult, missing []int) {
+	cached = make([]*ColorResult, len(keys))
+
+	if bypass {
+		for i := range keys {
+			missing = append(missing, i)
+		}
+
+		return cached, missing
+	}
+
+	cache := client.cache()
+	for i, key := range keys {
+		raw, ok := cache.Get(key)
+		if !ok {
+			missing = append(missing, i)
+			continue
+		}
+
+		result := new(ColorResult)
+		if err := json.Unmarshal(raw, result); err != nil {
+			missing = append(missing, i)
+			continue
+		}
+
+		cached[i] = result
+	}
+
+	return cached, missing
+}
+
+// assembleColorResp splices cached results back into original-request
+// order, using source (the response for any cache misses, or nil if
+// every input was a hit) for the envelope fields.
+func assembleColorResp(source *ColorResp, cached []*ColorResult) *ColorResp {
+	resp := &ColorResp{Results: cached}
+
+	if source != nil {
+		resp.StatusCode = source.StatusCode
+		resp.StatusMessage = source.StatusMessage
+	} else {
+		resp.StatusCode = "OK"
+		resp.StatusMessage = "OK"
+	}
+
+	return resp
+}
@@ -0,0 +1,213 @@
+package clarifai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultAPIRoot = "https://api.clarifai.com/v1/"
+
+// Client is the base client used to talk to the Clarifai API.
+type Client struct {
+	APIKey     string
+	APIRoot    string
+	HTTPClient *http.Client
+
+	// MaxRetries is the number of times a request is retried after a
+	// network error or a 429/5xx response before the error is returned
+	// to the caller. The default of 0 preserves the old behavior of
+	// never retrying. Only JSON requests are retried: multipart file
+	// uploads (Tag/Color with Files) are sent once.
+	MaxRetries int
+
+	// Backoff controls the pause between retries. If nil, NewClient's
+	// default exponential backoff is used.
+	Backoff BackoffStrategy
+
+	// ColorMode controls whether Color/ColorContext call the /color/
+	// endpoint, compute the palette locally, or try remote first and
+	// fall back to local on a network error. Defaults to ModeRemote.
+	ColorMode ColorMode
+
+	// InfoCacheTTL controls how long a fetched /info/ response is reused
+	// for upload limit checks before being re-fetched. Defaults to 5
+	// minutes when zero.
+	InfoCacheTTL time.Duration
+
+	// AllowedMIMETypes restricts which sniffed content types Tag/Color
+	// will upload. Defaults to defaultAllowedMIMETypes when empty.
+	AllowedMIMETypes []string
+
+	// Index backs Similar/Dissimilar and IndexAdd/IndexRemove. Defaults
+	// to a MemoryIndex when nil.
+	Index Index
+
+	// Cache backs Tag/Color response caching. Defaults to NoopCache, so
+	// caching is opt-in.
+	Cache Cache
+
+	// CacheTTL is passed to Cache.Set for every new entry. Zero means
+	// entries never expire on their own.
+	CacheTTL time.Duration
+
+	infoCache     infoCache
+	fileHashCache fileHashCache
+	indexOnce     sync.Once
+}
+
+// NewClient returns a Client configured with the given API key.
+func NewClient(apiKey string) *Client {
+	return &Client{
+		APIKey:     apiKey,
+		APIRoot:    defaultAPIRoot,
+		HTTPClient: http.DefaultClient,
+		Backoff:    NewExponentialBackoff(),
+		Index:      NewMemoryIndex(),
+	}
+}
+
+func (client *Client) apiRoot() string {
+	if client.APIRoot != "" {
+		return client.APIRoot
+	}
+
+	return defaultAPIRoot
+}
+
+func (client *Client) httpClient() *http.Client {
+	if client.HTTPClient != nil {
+		return client.HTTPClient
+	}
+
+	return http.DefaultClient
+}
+
+func (client *Client) backoff() BackoffStrategy {
+	if client.Backoff != nil {
+		return client.Backoff
+	}
+
+	return NewExponentialBackoff()
+}
+
+// commonHTTPRequest sends a JSON request to the given Clarifai endpoint,
+// retrying according to client.MaxRetries and client.Backoff.
+func (client *Client) commonHTTPRequest(ctx context.Context, req interface{}, path, method string, auth bool) ([]byte, error) {
+	var body []byte
+
+	if req != nil {
+		b, err := json.Marshal(req)
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+
+	backoff := client.backoff()
+	backoff.Reset()
+
+	var lastErr error
+
+	for attempt := 0; attempt <= client.MaxRetries; attempt++ {
+		var bodyReader io.Reader
+		if body != nil {
+			bodyReader = bytes.NewReader(body)
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, method, client.apiRoot()+path+"/", bodyReader)
+		if err != nil {
+			return nil, err
+		}
+
+		httpReq.Header.Set("Authorization", "Key "+client.APIKey)
+		if body != nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+
+		res, err := client.httpClient().Do(httpReq)
+		if err != nil {
+			lastErr = err
+
+			if attempt == client.MaxRetries {
+				break
+			}
+			if sleepErr := sleepForRetry(ctx, backoff, attempt, nil); sleepErr != nil {
+				return nil, sleepErr
+			}
+			continue
+		}
+
+		if shouldRetry(res.StatusCode) {
+			retryAfter := retryAfterDuration(res.Header.Get("Retry-After"))
+			res.Body.Close()
+			lastErr = fmt.Errorf("clarifai: received status %d", res.StatusCode)
+
+			if attempt == client.MaxRetries {
+				break
+			}
+			if sleepErr := sleepForRetry(ctx, backoff, attempt, retryAfter); sleepErr != nil {
+				return nil, sleepErr
+			}
+			continue
+		}
+
+		defer res.Body.Close()
+
+		return ioutil.ReadAll(res.Body)
+	}
+
+	return nil, lastErr
+}
+
+// shouldRetry reports whether an HTTP status code warrants a retry.
+func shouldRetry(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// retryAfterDuration parses a Retry-After header given in seconds,
+// returning nil if the header is absent or malformed.
+func retryAfterDuration(header string) *time.Duration {
+	if header == "" {
+		return nil
+	}
+
+	seconds, err := strconv.Atoi(header)
+	if err != nil {
+		return nil
+	}
+
+	d := time.Duration(seconds) * time.Second
+
+	return &d
+}
+
+// sleepForRetry pauses for the backoff's delay (or retryAfter, if it is
+// longer), returning ctx.Err() immediately if ctx is canceled first.
+func sleepForRetry(ctx context.Context, backoff BackoffStrategy, attempt int, retryAfter *time.Duration) error {
+	delay := backoff.Next(attempt)
+	if retryAfter != nil && *retryAfter > delay {
+		delay = *retryAfter
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,310 @@
+package clarifai
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+	"math/big"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// isNetworkError reports whether err represents a failure to reach the
+// server at all (DNS, dial, timeout) as opposed to an API-level error,
+// so ModeLocalFallback knows when it's safe to fall back to ColorLocal.
+func isNetworkError(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// ColorMode selects where Client.Color sources its palette from.
+type ColorMode int
+
+const (
+	// ModeRemote always calls the /color/ endpoint. This is the default.
+	ModeRemote ColorMode = iota
+
+	// ModeLocal always computes the palette locally, without calling the
+	// API or using API credits. URL inputs are still fetched over HTTP.
+	ModeLocal
+
+	// ModeLocalFallback calls the /color/ endpoint first, and falls back
+	// to the local extractor if that request fails with a network error.
+	ModeLocalFallback
+)
+
+const (
+	localColorK         = 5
+	localColorMaxIter   = 50
+	localColorEpsilon   = 1.0
+	localColorMaxPixels = 10000
+)
+
+// ColorLocal computes the dominant colors of req.Files and req.URLs
+// locally, by decoding each image and clustering its pixels with k-means
+// in RGB space. It never contacts the Clarifai API.
+func (client *Client) ColorLocal(req ColorRequest) (*ColorResp, error) {
+	if len(req.URLs) < 1 && len(req.Files) < 1 {
+		return nil, errors.New("Requires at least one file or url")
+	}
+
+	resp := &ColorResp{
+		StatusCode:    "OK",
+		StatusMessage: "OK",
+	}
+
+	for _, name := range req.Files {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := colorResultFromReader(f, "")
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("clarifai: %s: %w", name, err)
+		}
+
+		resp.Results = append(resp.Results, result)
+	}
+
+	for _, url := range req.URLs {
+		result, err := client.colorResultFromURL(url)
+		if err != nil {
+			return nil, fmt.Errorf("clarifai: %s: %w", url, err)
+		}
+
+		resp.Results = append(resp.Results, result)
+	}
+
+	return resp, nil
+}
+
+func (client *Client) colorResultFromURL(url string) (*ColorResult, error) {
+	res, err := client.httpClient().Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d fetching image", res.StatusCode)
+	}
+
+	return colorResultFromReader(res.Body, url)
+}
+
+func colorResultFromReader(r io.Reader, url string) (*ColorResult, error) {
+	var raw bytes.Buffer
+	img, _, err := image.Decode(io.TeeReader(r, &raw))
+	if err != nil {
+		return nil, err
+	}
+
+	centroids, densities := kmeansPalette(img, localColorK)
+
+	colors := make([]*Color, len(centroids))
+	for i, c := range centroids {
+		colors[i] = &Color{
+			Hex:     c.hex(),
+			Density: densities[i],
+			W3C:     nearestW3C(c),
+		}
+	}
+
+	docID, docIDString := docIDFromContent(raw.Bytes())
+
+	return &ColorResult{
+		DocID:       docID,
+		URL:         url,
+		Colors:      colors,
+		DocIDString: docIDString,
+	}, nil
+}
+
+// docIDFromContent derives a deterministic DocID/DocIDString pair from an
+// image's bytes, mirroring how the /color/ endpoint's docid identifies an
+// input, so callers can switch between ModeRemote and ModeLocal without
+// DocID-keyed code (e.g. Similar/Dissimilar/Feedback) breaking.
+func docIDFromContent(content []byte) (*big.Int, string) {
+	sum := sha256.Sum256(content)
+	docID := new(big.Int).SetBytes(sum[:])
+	return docID, docID.String()
+}
+
+type rgb struct {
+	r, g, b float64
+}
+
+func (c rgb) hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", clamp8(c.r), clamp8(c.g), clamp8(c.b))
+}
+
+func clamp8(v float64) int {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return int(v + 0.5)
+}
+
+func (c rgb) distSq(o rgb) float64 {
+	dr := c.r - o.r
+	dg := c.g - o.g
+	db := c.b - o.b
+	return dr*dr + dg*dg + db*db
+}
+
+// kmeansPalette clusters img's pixels into k centroids using k-means++
+// initialization, returning each centroid alongside the fraction of
+// sampled pixels assigned to it.
+func kmeansPalette(img image.Image, k int) ([]rgb, []float64) {
+	pixels := samplePixels(img, localColorMaxPixels)
+	if len(pixels) == 0 {
+		return nil, nil
+	}
+	if len(pixels) < k {
+		k = len(pixels)
+	}
+
+	centroids := kmeansPlusPlusInit(pixels, k)
+	assignments := make([]int, len(pixels))
+
+	for iter := 0; iter < localColorMaxIter; iter++ {
+		for i, p := range pixels {
+			assignments[i] = nearestCentroid(p, centroids)
+		}
+
+		moved := 0.0
+		for ci := range centroids {
+			var sum rgb
+			count := 0
+			for i, p := range pixels {
+				if assignments[i] != ci {
+					continue
+				}
+				sum.r += p.r
+				sum.g += p.g
+				sum.b += p.b
+				count++
+			}
+			if count == 0 {
+				continue
+			}
+
+			updated := rgb{sum.r / float64(count), sum.g / float64(count), sum.b / float64(count)}
+			moved += math.Sqrt(centroids[ci].distSq(updated))
+			centroids[ci] = updated
+		}
+
+		if moved < localColorEpsilon {
+			break
+		}
+	}
+
+	counts := make([]int, k)
+	for _, p := range pixels {
+		counts[nearestCentroid(p, centroids)]++
+	}
+
+	densities := make([]float64, k)
+	for i, count := range counts {
+		densities[i] = float64(count) / float64(len(pixels))
+	}
+
+	return centroids, densities
+}
+
+func samplePixels(img image.Image, maxPixels int) []rgb {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	total := width * height
+	if total == 0 {
+		return nil
+	}
+
+	stride := 1
+	for total/(stride*stride) > maxPixels {
+		stride++
+	}
+
+	pixels := make([]rgb, 0, maxPixels)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stride {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stride {
+			r, g, b, _ := img.At(x, y).RGBA()
+			pixels = append(pixels, rgb{float64(r >> 8), float64(g >> 8), float64(b >> 8)})
+		}
+	}
+
+	return pixels
+}
+
+func kmeansPlusPlusInit(pixels []rgb, k int) []rgb {
+	centroids := make([]rgb, 0, k)
+	centroids = append(centroids, pixels[rand.Intn(len(pixels))])
+
+	distSq := make([]float64, len(pixels))
+
+	for len(centroids) < k {
+		var total float64
+		for i, p := range pixels {
+			best := math.MaxFloat64
+			for _, c := range centroids {
+				if d := p.distSq(c); d < best {
+					best = d
+				}
+			}
+			distSq[i] = best
+			total += best
+		}
+
+		if total == 0 {
+			centroids = append(centroids, pixels[rand.Intn(len(pixels))])
+			continue
+		}
+
+		target := rand.Float64() * total
+		var cumulative float64
+		chosen := pixels[len(pixels)-1]
+		for i, d := range distSq {
+			cumulative += d
+			if cumulative >= target {
+				chosen = pixels[i]
+				break
+			}
+		}
+
+		centroids = append(centroids, chosen)
+	}
+
+	return centroids
+}
+
+func nearestCentroid(p rgb, centroids []rgb) int {
+	best := 0
+	bestDist := math.MaxFloat64
+	for i, c := range centroids {
+		if d := p.distSq(c); d < bestDist {
+			bestDist = d
+			best = i
+		}
+	}
+	return best
+}
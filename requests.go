@@ -1,6 +1,7 @@
 package clarifai
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"math/big"
@@ -30,6 +31,11 @@ type InfoResp struct {
 type ColorRequest struct {
 	URLs  []string `json:"url"`
 	Files []string `json:"files,omitempty"`
+
+	// CacheBypass skips Client.Cache lookups, forcing a fresh request
+	// even if every input is already cached. The fresh results are still
+	// stored in Client.Cache.
+	CacheBypass bool `json:"-"`
 }
 
 // GetFiles returns the files on the request.
@@ -76,6 +82,11 @@ type TagRequest struct {
 	Files    []string `json:"files,omitempty"`
 	LocalIDs []string `json:"local_ids,omitempty"`
 	Model    string   `json:"model,omitempty"`
+
+	// CacheBypass skips Client.Cache lookups, forcing a fresh request
+	// even if every input is already cached. The fresh results are still
+	// stored in Client.Cache.
+	CacheBypass bool `json:"-"`
 }
 
 // GetFiles returns the files on the request.
@@ -143,7 +154,13 @@ type hasFiles interface {
 
 // Info will return the current status info for the given client
 func (client *Client) Info() (*InfoResp, error) {
-	res, err := client.commonHTTPRequest(nil, "info", "GET", false)
+	return client.InfoContext(context.Background())
+}
+
+// InfoContext is like Info but observes ctx cancellation for the duration
+// of the request, including any retries.
+func (client *Client) InfoContext(ctx context.Context) (*InfoResp, error) {
+	res, err := client.commonHTTPRequest(ctx, nil, "info", "GET", false)
 
 	if err != nil {
 		return nil, err
@@ -157,6 +174,12 @@ func (client *Client) Info() (*InfoResp, error) {
 
 // Tag allows the client to request tag data on a single, or multiple photos
 func (client *Client) Tag(req TagRequest) (*TagResp, error) {
+	return client.TagContext(context.Background(), req)
+}
+
+// TagContext is like Tag but observes ctx cancellation for the duration of
+// the request, including any retries.
+func (client *Client) TagContext(ctx context.Context, req TagRequest) (*TagResp, error) {
 	if len(req.URLs) < 1 && len(req.Files) < 1 {
 		return nil, errors.New("Requires at least one file or url")
 	}
@@ -166,13 +189,50 @@ func (client *Client) Tag(req TagRequest) (*TagResp, error) {
 		return nil, errors.New("Can't submit both files and urls")
 	}
 
-	res := []byte{}
+	n := len(req.URLs) + len(req.Files)
+	cached := make([]*TagResult, n)
+	missing := make([]int, n)
+	for i := range missing {
+		missing[i] = i
+	}
+
+	var keys []string
 	var err error
+	if client.cacheEnabled() {
+		keys, err = client.inputCacheKeys(req.Model, req.URLs, req.Files)
+		if err != nil {
+			return nil, err
+		}
+
+		cached, missing = client.tagCacheHits(keys, req.CacheBypass)
+	}
+
+	if len(missing) == 0 {
+		return assembleTagResp(nil, cached), nil
+	}
+
+	missReq := TagRequest{Model: req.Model}
+	if len(req.URLs) > 0 {
+		for _, i := range missing {
+			missReq.URLs = append(missReq.URLs, req.URLs[i])
+		}
+	} else {
+		for _, i := range missing {
+			missReq.Files = append(missReq.Files, req.Files[i])
+		}
+	}
+	for _, i := range missing {
+		if i < len(req.LocalIDs) {
+			missReq.LocalIDs = append(missReq.LocalIDs, req.LocalIDs[i])
+		}
+	}
 
-	if len(req.Files) > 0 {
-		res, err = client.fileHTTPRequest(req, "tag", false)
+	res := []byte{}
+
+	if len(missReq.Files) > 0 {
+		res, err = client.fileHTTPRequest(ctx, missReq, "tag", false)
 	} else {
-		res, err = client.commonHTTPRequest(req, "tag", "POST", false)
+		res, err = client.commonHTTPRequest(ctx, missReq, "tag", "POST", false)
 	}
 
 	if err != nil {
@@ -180,13 +240,36 @@ func (client *Client) Tag(req TagRequest) (*TagResp, error) {
 	}
 
 	tagres := new(TagResp)
-	err = json.Unmarshal(res, tagres)
+	if err := json.Unmarshal(res, tagres); err != nil {
+		return nil, err
+	}
 
-	return tagres, err
+	for j, i := range missing {
+		if j >= len(tagres.Results) {
+			break
+		}
+
+		result := tagres.Results[j]
+		cached[i] = &result
+
+		if keys != nil {
+			if raw, err := json.Marshal(result); err == nil {
+				client.cache().Set(keys[i], raw, client.CacheTTL)
+			}
+		}
+	}
+
+	return assembleTagResp(tagres, cached), nil
 }
 
 // Color allows the client to request color data on a single, or multiple photos
 func (client *Client) Color(req ColorRequest) (*ColorResp, error) {
+	return client.ColorContext(context.Background(), req)
+}
+
+// ColorContext is like Color but observes ctx cancellation for the duration
+// of the request, including any retries.
+func (client *Client) ColorContext(ctx context.Context, req ColorRequest) (*ColorResp, error) {
 	if len(req.URLs) < 1 && len(req.Files) < 1 {
 		return nil, errors.New("Requires at least one file or url")
 	}
@@ -196,27 +279,89 @@ func (client *Client) Color(req ColorRequest) (*ColorResp, error) {
 		return nil, errors.New("Can't submit both files and urls")
 	}
 
-	res := []byte{}
+	if client.ColorMode == ModeLocal {
+		return client.ColorLocal(req)
+	}
+
+	n := len(req.URLs) + len(req.Files)
+	cached := make([]*ColorResult, n)
+	missing := make([]int, n)
+	for i := range missing {
+		missing[i] = i
+	}
+
+	var keys []string
 	var err error
+	if client.cacheEnabled() {
+		keys, err = client.inputCacheKeys("", req.URLs, req.Files)
+		if err != nil {
+			return nil, err
+		}
+
+		cached, missing = client.colorCacheHits(keys, req.CacheBypass)
+	}
+
+	if len(missing) == 0 {
+		return assembleColorResp(nil, cached), nil
+	}
 
-	if len(req.Files) > 0 {
-		res, err = client.fileHTTPRequest(req, "color", false)
+	missReq := ColorRequest{}
+	if len(req.URLs) > 0 {
+		for _, i := range missing {
+			missReq.URLs = append(missReq.URLs, req.URLs[i])
+		}
+	} else {
+		for _, i := range missing {
+			missReq.Files = append(missReq.Files, req.Files[i])
+		}
+	}
+
+	res := []byte{}
+
+	if len(missReq.Files) > 0 {
+		res, err = client.fileHTTPRequest(ctx, missReq, "color", false)
 	} else {
-		res, err = client.commonHTTPRequest(req, "color", "POST", false)
+		res, err = client.commonHTTPRequest(ctx, missReq, "color", "POST", false)
 	}
 
 	if err != nil {
+		if client.ColorMode == ModeLocalFallback && isNetworkError(err) {
+			return client.ColorLocal(req)
+		}
 		return nil, err
 	}
 
 	colorRes := new(ColorResp)
-	err = json.Unmarshal(res, colorRes)
+	if err := json.Unmarshal(res, colorRes); err != nil {
+		return nil, err
+	}
+
+	for j, i := range missing {
+		if j >= len(colorRes.Results) {
+			break
+		}
+
+		result := colorRes.Results[j]
+		cached[i] = result
 
-	return colorRes, err
+		if keys != nil {
+			if raw, err := json.Marshal(result); err == nil {
+				client.cache().Set(keys[i], raw, client.CacheTTL)
+			}
+		}
+	}
+
+	return assembleColorResp(colorRes, cached), nil
 }
 
 // Feedback allows the user to provide contextual feedback to Clarifai in order to improve their results
 func (client *Client) Feedback(form FeedbackForm) (*FeedbackResp, error) {
+	return client.FeedbackContext(context.Background(), form)
+}
+
+// FeedbackContext is like Feedback but observes ctx cancellation for the
+// duration of the request, including any retries.
+func (client *Client) FeedbackContext(ctx context.Context, form FeedbackForm) (*FeedbackResp, error) {
 	if form.DocIDs == nil && form.URLs == nil {
 		return nil, errors.New("Requires at least one docid or url")
 	}
@@ -225,10 +370,18 @@ func (client *Client) Feedback(form FeedbackForm) (*FeedbackResp, error) {
 		return nil, errors.New("Request must provide exactly one of the following fields: {'DocIDs', 'URLs'}")
 	}
 
-	res, err := client.commonHTTPRequest(form, "feedback", "POST", false)
+	res, err := client.commonHTTPRequest(ctx, form, "feedback", "POST", false)
+	if err != nil {
+		return nil, err
+	}
 
 	feedbackres := new(FeedbackResp)
 	err = json.Unmarshal(res, feedbackres)
+	if err != nil {
+		return nil, err
+	}
+
+	client.applyFeedbackToIndex(form)
 
 	return feedbackres, err
 
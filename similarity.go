@@ -0,0 +1,400 @@
+package clarifai
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"sort"
+	"sync"
+)
+
+// SimilarRequest queries the client's similarity index for neighbors of
+// one or more inputs, which may already be indexed (DocIDs) or be
+// tagged on the fly (URLs, Files).
+type SimilarRequest struct {
+	DocIDs            []string
+	URLs              []string
+	Files             []string
+	Model             string
+	Count             int
+	IncludeNotPresent bool
+}
+
+// SimilarResp is the result of a Similar or Dissimilar query.
+type SimilarResp struct {
+	Results []SimilarResult
+}
+
+// SimilarResult holds the ranked neighbors for a single query input.
+type SimilarResult struct {
+	Query     string
+	Neighbors []Neighbor
+}
+
+// Neighbor is one ranked match returned by Similar/Dissimilar.
+type Neighbor struct {
+	DocID string
+	URL   string
+	Score float32
+}
+
+// IndexEntry is a single feature vector stored in an Index.
+type IndexEntry struct {
+	DocID string
+	URL   string
+	Vec   []float32
+}
+
+// Index stores feature vectors keyed by DocID and ranks them by
+// similarity to a query vector. Client's default is MemoryIndex;
+// callers can assign Client.Index to back it with a real ANN store
+// instead.
+type Index interface {
+	Add(entry IndexEntry)
+	Remove(docID string)
+	Get(docID string) (IndexEntry, bool)
+
+	// Nearest returns the top count entries by cosine similarity to query,
+	// ranked highest-first.
+	Nearest(query []float32, count int) []Neighbor
+
+	// Farthest returns the top count entries by cosine similarity to
+	// query, ranked lowest-first. It ranks the whole index, not the tail
+	// of Nearest's result.
+	Farthest(query []float32, count int) []Neighbor
+}
+
+// MemoryIndex is the default Index: an in-process slice of entries
+// ranked by cosine similarity.
+type MemoryIndex struct {
+	mu      sync.RWMutex
+	entries []IndexEntry
+}
+
+// NewMemoryIndex returns an empty MemoryIndex.
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{}
+}
+
+// Add inserts entry, replacing any existing entry with the same DocID.
+func (idx *MemoryIndex) Add(entry IndexEntry) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for i, e := range idx.entries {
+		if e.DocID == entry.DocID {
+			idx.entries[i] = entry
+			return
+		}
+	}
+
+	idx.entries = append(idx.entries, entry)
+}
+
+// Remove deletes the entry for docID, if present.
+func (idx *MemoryIndex) Remove(docID string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for i, e := range idx.entries {
+		if e.DocID == docID {
+			idx.entries = append(idx.entries[:i], idx.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// Get returns the stored entry for docID.
+func (idx *MemoryIndex) Get(docID string) (IndexEntry, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	for _, e := range idx.entries {
+		if e.DocID == docID {
+			return e, true
+		}
+	}
+
+	return IndexEntry{}, false
+}
+
+// Nearest ranks every stored entry by cosine similarity to query and
+// returns the top count, highest-first.
+func (idx *MemoryIndex) Nearest(query []float32, count int) []Neighbor {
+	return idx.rank(query, count, false)
+}
+
+// Farthest ranks every stored entry by cosine similarity to query and
+// returns the top count, lowest-first.
+func (idx *MemoryIndex) Farthest(query []float32, count int) []Neighbor {
+	return idx.rank(query, count, true)
+}
+
+func (idx *MemoryIndex) rank(query []float32, count int, ascending bool) []Neighbor {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	neighbors := make([]Neighbor, 0, len(idx.entries))
+	for _, e := range idx.entries {
+		neighbors = append(neighbors, Neighbor{
+			DocID: e.DocID,
+			URL:   e.URL,
+			Score: cosineSimilarity(query, e.Vec),
+		})
+	}
+
+	sort.Slice(neighbors, func(i, j int) bool {
+		if ascending {
+			return neighbors[i].Score < neighbors[j].Score
+		}
+		return neighbors[i].Score > neighbors[j].Score
+	})
+
+	if count > 0 && len(neighbors) > count {
+		neighbors = neighbors[:count]
+	}
+
+	return neighbors
+}
+
+func cosineSimilarity(a, b []float32) float32 {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+
+	var dot, na, nb float64
+	for i := 0; i < n; i++ {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+
+	if na == 0 || nb == 0 {
+		return 0
+	}
+
+	return float32(dot / (math.Sqrt(na) * math.Sqrt(nb)))
+}
+
+// index returns client.Index, initializing it to a MemoryIndex on first
+// use if it's unset. indexOnce makes that lazy init safe for concurrent
+// first calls to Similar/Dissimilar/IndexAdd/Feedback on a Client built
+// without NewClient, without the earlier bug of handing back a fresh,
+// throwaway MemoryIndex on every call (which silently discarded anything
+// IndexAdd/Feedback stored).
+func (client *Client) index() Index {
+	client.indexOnce.Do(func() {
+		if client.Index == nil {
+			client.Index = NewMemoryIndex()
+		}
+	})
+
+	return client.Index
+}
+
+// Similar returns the ranked neighbors of req's inputs.
+func (client *Client) Similar(req SimilarRequest) (*SimilarResp, error) {
+	return client.SimilarContext(context.Background(), req)
+}
+
+// SimilarContext is like Similar but observes ctx cancellation.
+func (client *Client) SimilarContext(ctx context.Context, req SimilarRequest) (*SimilarResp, error) {
+	return client.rank(ctx, req, 1)
+}
+
+// Dissimilar is the mirror of Similar: it returns neighbors ranked from
+// least to most similar.
+func (client *Client) Dissimilar(req SimilarRequest) (*SimilarResp, error) {
+	return client.DissimilarContext(context.Background(), req)
+}
+
+// DissimilarContext is like Dissimilar but observes ctx cancellation.
+func (client *Client) DissimilarContext(ctx context.Context, req SimilarRequest) (*SimilarResp, error) {
+	return client.rank(ctx, req, -1)
+}
+
+func (client *Client) rank(ctx context.Context, req SimilarRequest, sign float32) (*SimilarResp, error) {
+	queries := append(append(append([]string{}, req.DocIDs...), req.URLs...), req.Files...)
+	if len(queries) == 0 {
+		return nil, errors.New("Requires at least one docid, url, or file")
+	}
+
+	count := req.Count
+	if count <= 0 {
+		count = 10
+	}
+
+	resp := &SimilarResp{}
+
+	for _, q := range queries {
+		vec, err := client.queryVector(ctx, req.Model, q)
+		if err != nil {
+			return nil, err
+		}
+
+		var neighbors []Neighbor
+		if sign < 0 {
+			// Rank the whole index lowest-first rather than sorting the
+			// already-truncated Nearest() result, which would only ever
+			// reflect the tail of the "most similar" set.
+			neighbors = client.index().Farthest(vec, count)
+		} else {
+			neighbors = client.index().Nearest(vec, count)
+		}
+
+		if !req.IncludeNotPresent {
+			neighbors = withoutZeroScores(neighbors)
+		}
+
+		resp.Results = append(resp.Results, SimilarResult{Query: q, Neighbors: neighbors})
+	}
+
+	return resp, nil
+}
+
+func withoutZeroScores(neighbors []Neighbor) []Neighbor {
+	out := neighbors[:0]
+	for _, n := range neighbors {
+		if n.Score != 0 {
+			out = append(out, n)
+		}
+	}
+
+	return out
+}
+
+// queryVector resolves q to a feature vector: if q is a DocID already
+// in the index, its stored vector is reused; otherwise q is treated as
+// a URL or file path and tagged on the fly.
+func (client *Client) queryVector(ctx context.Context, model, q string) ([]float32, error) {
+	if entry, ok := client.index().Get(q); ok {
+		return entry.Vec, nil
+	}
+
+	return client.vectorFor(ctx, model, q)
+}
+
+// vectorFor tags input (a local file path or a URL) and returns its
+// classification probabilities as a feature vector. The Clarifai v1 API
+// has no dedicated embeddings endpoint, so Probs doubles as the vector
+// Similar/Dissimilar rank against.
+func (client *Client) vectorFor(ctx context.Context, model, input string) ([]float32, error) {
+	req := TagRequest{Model: model}
+
+	if _, err := os.Stat(input); err == nil {
+		req.Files = []string{input}
+	} else {
+		req.URLs = []string{input}
+	}
+
+	resp, err := client.TagContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Results) == 0 {
+		return nil, errors.New("clarifai: tag response had no results")
+	}
+
+	return resp.Results[0].Result.Tag.Probs, nil
+}
+
+// IndexAdd tags each of inputs (local file paths or URLs) and stores the
+// average feature vector in the client's Index under docID.
+func (client *Client) IndexAdd(ctx context.Context, docID string, inputs ...string) error {
+	if len(inputs) == 0 {
+		return errors.New("Requires at least one file or url")
+	}
+
+	var sum []float32
+	var url string
+
+	for _, input := range inputs {
+		vec, err := client.vectorFor(ctx, "", input)
+		if err != nil {
+			return err
+		}
+
+		if sum == nil {
+			sum = make([]float32, len(vec))
+		}
+		for i := 0; i < len(vec) && i < len(sum); i++ {
+			sum[i] += vec[i]
+		}
+
+		if url == "" {
+			if _, err := os.Stat(input); err != nil {
+				url = input
+			}
+		}
+	}
+
+	for i := range sum {
+		sum[i] /= float32(len(inputs))
+	}
+
+	client.index().Add(IndexEntry{DocID: docID, URL: url, Vec: sum})
+
+	return nil
+}
+
+// IndexRemove deletes docID from the client's Index.
+func (client *Client) IndexRemove(docID string) {
+	client.index().Remove(docID)
+}
+
+// feedbackLearningRate is how far applyFeedbackToIndex nudges an
+// anchor's vector toward a positive (or away from a negative) neighbor
+// on each call.
+const feedbackLearningRate = 0.05
+
+// applyFeedbackToIndex implements the metric-learning-style update:
+// for every DocID in form.DocIDs that's already indexed, nudge its
+// vector a small step toward SimilarDocIDs and away from
+// DissimilarDocIDs.
+func (client *Client) applyFeedbackToIndex(form FeedbackForm) {
+	if len(form.SimilarDocIDs) == 0 && len(form.DissimilarDocIDs) == 0 {
+		return
+	}
+
+	idx := client.index()
+
+	for _, anchorID := range form.DocIDs {
+		entry, ok := idx.Get(anchorID)
+		if !ok {
+			continue
+		}
+
+		// Copy before mutating: entry.Vec shares a backing array with the
+		// slice idx has stored, and neither Get's nor Add's lock covers
+		// this read-modify-write, so nudging in place would race with a
+		// concurrent Feedback call touching the same anchor.
+		vec := append([]float32(nil), entry.Vec...)
+
+		nudgeVector(vec, idx, form.SimilarDocIDs, 1)
+		nudgeVector(vec, idx, form.DissimilarDocIDs, -1)
+
+		entry.Vec = vec
+		idx.Add(entry)
+	}
+}
+
+func nudgeVector(vec []float32, idx Index, docIDs []string, sign float32) {
+	for _, id := range docIDs {
+		other, ok := idx.Get(id)
+		if !ok {
+			continue
+		}
+
+		n := len(vec)
+		if len(other.Vec) < n {
+			n = len(other.Vec)
+		}
+
+		for i := 0; i < n; i++ {
+			vec[i] += sign * feedbackLearningRate * (other.Vec[i] - vec[i])
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package clarifai
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestClientIndexPersistsAcrossCalls(t *testing.T) {
+	client := &Client{}
+
+	client.index().Add(IndexEntry{DocID: "doc1", Vec: []float32{1, 0}})
+
+	entry, ok := client.index().Get("doc1")
+	if !ok {
+		t.Fatal("doc1 is missing on a later call to client.index() - the default index isn't persisting")
+	}
+	if entry.DocID != "doc1" {
+		t.Fatalf("got DocID %q, want doc1", entry.DocID)
+	}
+}
+
+func TestClientIndexConcurrentFirstUse(t *testing.T) {
+	client := &Client{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.index().Add(IndexEntry{DocID: "doc", Vec: []float32{1}})
+		}()
+	}
+	wg.Wait()
+
+	if _, ok := client.index().Get("doc"); !ok {
+		t.Fatal("doc is missing after concurrent first use of client.index()")
+	}
+}
+
+func TestApplyFeedbackToIndexConcurrent(t *testing.T) {
+	client := &Client{Index: NewMemoryIndex()}
+	client.index().Add(IndexEntry{DocID: "anchor", Vec: []float32{0, 0}})
+	client.index().Add(IndexEntry{DocID: "pos", Vec: []float32{1, 1}})
+	client.index().Add(IndexEntry{DocID: "neg", Vec: []float32{-1, -1}})
+
+	form := FeedbackForm{
+		DocIDs:           []string{"anchor"},
+		SimilarDocIDs:    []string{"pos"},
+		DissimilarDocIDs: []string{"neg"},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.applyFeedbackToIndex(form)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,231 @@
+package clarifai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrFileTooLarge is returned when a file exceeds the MaxImageBytes
+// limit reported by /info/ before it finishes streaming to the API.
+type ErrFileTooLarge struct {
+	Filename string
+	Limit    int64
+	Observed int64
+}
+
+func (e *ErrFileTooLarge) Error() string {
+	return fmt.Sprintf("clarifai: %s exceeds the %d byte limit (read at least %d bytes)", e.Filename, e.Limit, e.Observed)
+}
+
+// defaultAllowedMIMETypes is the sniffed-content-type allow-list used
+// when Client.AllowedMIMETypes is empty.
+var defaultAllowedMIMETypes = []string{
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"image/bmp",
+	"image/webp",
+}
+
+// defaultInfoCacheTTL is how long a fetched InfoResp is reused for
+// before cachedInfo fetches it again.
+const defaultInfoCacheTTL = 5 * time.Minute
+
+// infoCache memoizes the client's /info/ limits so every upload doesn't
+// pay for a round-trip just to learn MaxImageBytes.
+type infoCache struct {
+	mu        sync.Mutex
+	info      *InfoResp
+	fetchedAt time.Time
+}
+
+func (client *Client) cachedInfo(ctx context.Context) (*InfoResp, error) {
+	client.infoCache.mu.Lock()
+	defer client.infoCache.mu.Unlock()
+
+	ttl := client.InfoCacheTTL
+	if ttl <= 0 {
+		ttl = defaultInfoCacheTTL
+	}
+
+	if client.infoCache.info != nil && time.Since(client.infoCache.fetchedAt) < ttl {
+		return client.infoCache.info, nil
+	}
+
+	info, err := client.InfoContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client.infoCache.info = info
+	client.infoCache.fetchedAt = time.Now()
+
+	return info, nil
+}
+
+// uploadLimits bundles the /info/ limits addFilePart enforces per file,
+// so callers don't have to thread three separate ints through
+// writeMultipartFiles.
+type uploadLimits struct {
+	maxBytes int64
+	minSize  int
+	maxSize  int
+}
+
+// fileHTTPRequest uploads files as a multipart request to the given
+// Clarifai endpoint. Each file is streamed through an io.Pipe rather
+// than buffered fully in memory, and is rejected before it finishes
+// uploading if it exceeds the limits reported by /info/.
+func (client *Client) fileHTTPRequest(ctx context.Context, req hasFiles, path string, auth bool) ([]byte, error) {
+	info, err := client.cachedInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if max := info.Results.MaxBatchSize; max > 0 && len(req.GetFiles()) > max {
+		return nil, fmt.Errorf("clarifai: %d files exceeds the batch limit of %d", len(req.GetFiles()), max)
+	}
+
+	limits := uploadLimits{
+		maxBytes: int64(info.Results.MaxImageBytes), // 0 means unset; treated as "no limit" below
+		minSize:  info.Results.MinImageSize,
+		maxSize:  info.Results.MaxImageSize,
+	}
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		if err := client.writeMultipartFiles(writer, req, limits); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+
+		pw.CloseWithError(writer.Close())
+	}()
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", client.apiRoot()+path+"/", pr)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Authorization", "Key "+client.APIKey)
+	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
+
+	res, err := client.httpClient().Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	return ioutil.ReadAll(res.Body)
+}
+
+func (client *Client) writeMultipartFiles(writer *multipart.Writer, req hasFiles, limits uploadLimits) error {
+	for _, name := range req.GetFiles() {
+		if err := client.addFilePart(writer, name, limits); err != nil {
+			return err
+		}
+	}
+
+	if model := req.GetModel(); model != "" {
+		if err := writer.WriteField("model", model); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addFilePart streams name into writer as a single part, sniffing its
+// content type from the first 512 bytes and enforcing limits along the
+// way.
+func (client *Client) addFilePart(writer *multipart.Writer, name string, limits uploadLimits) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	limitN := int64(math.MaxInt64)
+	if limits.maxBytes > 0 {
+		limitN = limits.maxBytes + 1
+	}
+	limited := &io.LimitedReader{R: f, N: limitN}
+
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(limited, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	sniff = sniff[:n]
+
+	contentType := http.DetectContentType(sniff)
+	if !client.mimeAllowed(contentType) {
+		return fmt.Errorf("clarifai: %s has disallowed content type %q", name, contentType)
+	}
+
+	// DecodeConfig only needs the header, so it can work off the 512-byte
+	// sniff already in hand. If the format's header doesn't fit in that
+	// (or sniff isn't a registered image format), skip the dimension
+	// check rather than read the file again just to force a decode.
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(sniff)); err == nil {
+		if limits.maxSize > 0 && (cfg.Width > limits.maxSize || cfg.Height > limits.maxSize) {
+			return fmt.Errorf("clarifai: %s is %dx%d, exceeds the %d pixel limit", name, cfg.Width, cfg.Height, limits.maxSize)
+		}
+		if limits.minSize > 0 && (cfg.Width < limits.minSize || cfg.Height < limits.minSize) {
+			return fmt.Errorf("clarifai: %s is %dx%d, smaller than the %d pixel minimum", name, cfg.Width, cfg.Height, limits.minSize)
+		}
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="encoded_image"; filename=%q`, filepath.Base(name)))
+	header.Set("Content-Type", contentType)
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	if _, err := part.Write(sniff); err != nil {
+		return err
+	}
+
+	written, err := io.Copy(part, limited)
+	if err != nil {
+		return err
+	}
+
+	if total := int64(len(sniff)) + written; limits.maxBytes > 0 && total > limits.maxBytes {
+		return &ErrFileTooLarge{Filename: name, Limit: limits.maxBytes, Observed: total}
+	}
+
+	return nil
+}
+
+func (client *Client) mimeAllowed(contentType string) bool {
+	allowed := client.AllowedMIMETypes
+	if len(allowed) == 0 {
+		allowed = defaultAllowedMIMETypes
+	}
+
+	for _, a := range allowed {
+		if a == contentType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,84 @@
+package clarifai
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestPNG(t *testing.T, dir, name string, width, height int) string {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestAddFilePartZeroMaxBytesMeansNoLimit(t *testing.T) {
+	client := &Client{}
+	path := writeTestPNG(t, t.TempDir(), "in.png", 20, 20)
+
+	writer := multipart.NewWriter(&bytes.Buffer{})
+
+	if err := client.addFilePart(writer, path, uploadLimits{}); err != nil {
+		t.Fatalf("addFilePart with a zero-valued MaxImageBytes: %v", err)
+	}
+}
+
+func TestAddFilePartEnforcesMaxBytes(t *testing.T) {
+	client := &Client{}
+	path := writeTestPNG(t, t.TempDir(), "in.png", 200, 200)
+
+	writer := multipart.NewWriter(&bytes.Buffer{})
+
+	err := client.addFilePart(writer, path, uploadLimits{maxBytes: 10})
+
+	var tooLarge *ErrFileTooLarge
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("got error %v, want *ErrFileTooLarge", err)
+	}
+}
+
+func TestAddFilePartEnforcesMaxImageSize(t *testing.T) {
+	client := &Client{}
+	path := writeTestPNG(t, t.TempDir(), "in.png", 500, 500)
+
+	writer := multipart.NewWriter(&bytes.Buffer{})
+
+	if err := client.addFilePart(writer, path, uploadLimits{maxSize: 100}); err == nil {
+		t.Fatal("expected an error for an image wider than MaxImageSize")
+	}
+}
+
+func TestAddFilePartEnforcesMinImageSize(t *testing.T) {
+	client := &Client{}
+	path := writeTestPNG(t, t.TempDir(), "in.png", 10, 10)
+
+	writer := multipart.NewWriter(&bytes.Buffer{})
+
+	if err := client.addFilePart(writer, path, uploadLimits{minSize: 100}); err == nil {
+		t.Fatal("expected an error for an image smaller than MinImageSize")
+	}
+}
@@ -0,0 +1,164 @@
+package clarifai
+
+import (
+	"math"
+	"strconv"
+)
+
+// w3cColor is one entry in the embedded W3C basic/extended color-name
+// table, referenced by nearestW3C for matching.
+type w3cColor struct {
+	name string
+	hex  string
+	lab  lab
+}
+
+// w3cColors is a 36-entry subset of the W3C basic/extended color names
+// (CSS Color Module Level 3 "extended keywords"), precomputed into Lab
+// space so nearestW3C can do a single pass of perceptual distance
+// comparisons. Names not listed here are never returned.
+var w3cColors = buildW3CTable(map[string]string{
+	"black":     "#000000",
+	"white":     "#ffffff",
+	"red":       "#ff0000",
+	"lime":      "#00ff00",
+	"blue":      "#0000ff",
+	"yellow":    "#ffff00",
+	"cyan":      "#00ffff",
+	"magenta":   "#ff00ff",
+	"silver":    "#c0c0c0",
+	"gray":      "#808080",
+	"maroon":    "#800000",
+	"olive":     "#808000",
+	"green":     "#008000",
+	"purple":    "#800080",
+	"teal":      "#008080",
+	"navy":      "#000080",
+	"orange":    "#ffa500",
+	"pink":      "#ffc0cb",
+	"brown":     "#a52a2a",
+	"gold":      "#ffd700",
+	"beige":     "#f5f5dc",
+	"khaki":     "#f0e68c",
+	"coral":     "#ff7f50",
+	"salmon":    "#fa8072",
+	"ivory":     "#fffff0",
+	"lavender":  "#e6e6fa",
+	"indigo":    "#4b0082",
+	"violet":    "#ee82ee",
+	"crimson":   "#dc143c",
+	"chocolate": "#d2691e",
+	"tan":       "#d2b48c",
+	"turquoise": "#40e0d0",
+	"plum":      "#dda0dd",
+	"orchid":    "#da70d6",
+	"skyblue":   "#87ceeb",
+	"slategray": "#708090",
+})
+
+func buildW3CTable(names map[string]string) []w3cColor {
+	table := make([]w3cColor, 0, len(names))
+	for name, hex := range names {
+		c, ok := parseHex(hex)
+		if !ok {
+			continue
+		}
+		table = append(table, w3cColor{name: name, hex: hex, lab: rgbToLab(c)})
+	}
+	return table
+}
+
+func parseHex(hex string) (rgb, bool) {
+	if len(hex) != 7 || hex[0] != '#' {
+		return rgb{}, false
+	}
+
+	r, err := strconv.ParseInt(hex[1:3], 16, 32)
+	if err != nil {
+		return rgb{}, false
+	}
+	g, err := strconv.ParseInt(hex[3:5], 16, 32)
+	if err != nil {
+		return rgb{}, false
+	}
+	b, err := strconv.ParseInt(hex[5:7], 16, 32)
+	if err != nil {
+		return rgb{}, false
+	}
+
+	return rgb{float64(r), float64(g), float64(b)}, true
+}
+
+// nearestW3C returns the W3C color whose Lab value is perceptually
+// closest to c, matching how a human would name the color rather than
+// its raw RGB distance.
+func nearestW3C(c rgb) W3C {
+	target := rgbToLab(c)
+
+	best := w3cColors[0]
+	bestDist := labDistSq(target, best.lab)
+
+	for _, candidate := range w3cColors[1:] {
+		if d := labDistSq(target, candidate.lab); d < bestDist {
+			bestDist = d
+			best = candidate
+		}
+	}
+
+	return W3C{Hex: best.hex, Name: best.name}
+}
+
+type lab struct {
+	l, a, b float64
+}
+
+func labDistSq(a, b lab) float64 {
+	dl := a.l - b.l
+	da := a.a - b.a
+	db := a.b - b.b
+	return dl*dl + da*da + db*db
+}
+
+// rgbToLab converts an sRGB color (0-255 per channel) to CIE Lab via the
+// standard sRGB -> linear RGB -> XYZ (D65) -> Lab pipeline.
+func rgbToLab(c rgb) lab {
+	r := srgbToLinear(c.r / 255)
+	g := srgbToLinear(c.g / 255)
+	b := srgbToLinear(c.b / 255)
+
+	x := r*0.4124564 + g*0.3575761 + b*0.1804375
+	y := r*0.2126729 + g*0.7151522 + b*0.0721750
+	z := r*0.0193339 + g*0.1191920 + b*0.9503041
+
+	// D65 reference white.
+	const (
+		xn = 0.95047
+		yn = 1.00000
+		zn = 1.08883
+	)
+
+	fx := labF(x / xn)
+	fy := labF(y / yn)
+	fz := labF(z / zn)
+
+	return lab{
+		l: 116*fy - 16,
+		a: 500 * (fx - fy),
+		b: 200 * (fy - fz),
+	}
+}
+
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func labF(t float64) float64 {
+	const delta = 6.0 / 29.0
+	if t > delta*delta*delta {
+		return math.Cbrt(t)
+	}
+	return t/(3*delta*delta) + 4.0/29.0
+}